@@ -0,0 +1,54 @@
+package couchbase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/acstech/doppler-api/internal/metrics"
+	"gopkg.in/couchbase/gocb.v1"
+)
+
+// Couchbase wraps a connection to a Couchbase bucket.
+type Couchbase struct {
+	Bucket *gocb.Bucket
+}
+
+// ConnectToCB connects to the Couchbase cluster described by connStr and
+// opens the default bucket, storing it on the receiver for later use.
+func (c *Couchbase) ConnectToCB(connStr string) error {
+	cluster, err := gocb.Connect(connStr)
+	if err != nil {
+		return fmt.Errorf("error connecting to couchbase cluster: %v", err)
+	}
+
+	bucket, err := cluster.OpenBucket("default", "")
+	if err != nil {
+		return fmt.Errorf("error opening couchbase bucket: %v", err)
+	}
+
+	c.Bucket = bucket
+	return nil
+}
+
+// Ping reports whether the bucket is reachable, using gocb's built-in KV
+// ping rather than a live key lookup.
+func (c *Couchbase) Ping() error {
+	if c.Bucket == nil {
+		return errors.New("couchbase bucket is not connected")
+	}
+
+	start := time.Now()
+	report, err := c.Bucket.Ping([]gocb.ServiceType{gocb.MemdService})
+	metrics.CouchbaseLatency.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		return err
+	}
+	for _, service := range report.Services {
+		if !service.Success {
+			return fmt.Errorf("couchbase ping failed against service %v endpoint %s", service.Service, service.Endpoint)
+		}
+	}
+	return nil
+}
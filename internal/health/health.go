@@ -0,0 +1,141 @@
+// Package health provides liveness and readiness checks so doppler-api
+// can be operated under Kubernetes without external tooling.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/acstech/doppler-api/internal/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultLivenessInterval is how often the liveness heartbeat goroutine
+// publishes, modeled on voltha-lib-go's DefaultLivenessChannelInterval.
+const DefaultLivenessInterval = 30 * time.Second
+
+// PingFunc reports whether a downstream dependency is reachable.
+type PingFunc func() error
+
+// Checker tracks the signals readiness and liveness are derived from:
+// recent Kafka consumer errors, downstream dependency pings, and a
+// periodic liveness heartbeat.
+type Checker struct {
+	errorWindow      time.Duration
+	livenessInterval time.Duration
+	couchbasePing    PingFunc
+	influxPing       PingFunc
+
+	lastErrorAt    int64 // unix nanoseconds, set via atomic.StoreInt64
+	lastHeartbeat  int64 // unix nanoseconds, set via atomic.StoreInt64
+	heartbeatStart sync.Once
+}
+
+// NewChecker creates a Checker. errorWindow bounds how long a Kafka
+// consumer error keeps readiness degraded; livenessInterval is how often
+// the liveness heartbeat is expected.
+func NewChecker(errorWindow time.Duration, livenessInterval time.Duration, couchbasePing PingFunc, influxPing PingFunc) *Checker {
+	return &Checker{
+		errorWindow:      errorWindow,
+		livenessInterval: livenessInterval,
+		couchbasePing:    couchbasePing,
+		influxPing:       influxPing,
+	}
+}
+
+// WatchErrors records the time of every error received on errs, so
+// readiness can degrade within errorWindow of a Kafka consumer error. It
+// blocks until ctx is done or errs is closed, and should be run in its
+// own goroutine.
+func (c *Checker) WatchErrors(ctx context.Context, errs <-chan error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			metrics.KafkaErrors.Inc()
+			log.Error().Err(err).Msg("kafka consumer group error")
+			atomic.StoreInt64(&c.lastErrorAt, time.Now().UnixNano())
+		}
+	}
+}
+
+// StartHeartbeat publishes a heartbeat every livenessInterval until ctx is
+// done. It starts its own goroutine and returns immediately; calling it
+// more than once is a no-op after the first call.
+func (c *Checker) StartHeartbeat(ctx context.Context) {
+	c.heartbeatStart.Do(func() {
+		atomic.StoreInt64(&c.lastHeartbeat, time.Now().UnixNano())
+		go func() {
+			ticker := time.NewTicker(c.livenessInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					atomic.StoreInt64(&c.lastHeartbeat, time.Now().UnixNano())
+				}
+			}
+		}()
+	})
+}
+
+// LivenessHandler reports unhealthy if no heartbeat has been observed
+// within two liveness intervals, which indicates the main loop has
+// wedged rather than merely being between ticks.
+func (c *Checker) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		last := atomic.LoadInt64(&c.lastHeartbeat)
+		if last == 0 || time.Since(time.Unix(0, last)) > 2*c.livenessInterval {
+			http.Error(w, "no liveness heartbeat observed", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// readinessReport is the JSON body written by ReadinessHandler.
+type readinessReport struct {
+	Ready     bool   `json:"ready"`
+	Couchbase string `json:"couchbase"`
+	Influx    string `json:"influx"`
+	Kafka     string `json:"kafka"`
+}
+
+// ReadinessHandler reports degraded when Couchbase or Influx are
+// unreachable, or when a Kafka consumer error has been observed within
+// errorWindow.
+func (c *Checker) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := readinessReport{Ready: true, Couchbase: "ok", Influx: "ok", Kafka: "ok"}
+
+		if err := c.couchbasePing(); err != nil {
+			report.Ready = false
+			report.Couchbase = err.Error()
+		}
+		if err := c.influxPing(); err != nil {
+			report.Ready = false
+			report.Influx = err.Error()
+		}
+		if lastErrorAt := atomic.LoadInt64(&c.lastErrorAt); lastErrorAt != 0 && time.Since(time.Unix(0, lastErrorAt)) < c.errorWindow {
+			report.Ready = false
+			report.Kafka = "recent consumer error"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			log.Error().Err(err).Msg("error encoding readiness report")
+		}
+	}
+}
@@ -0,0 +1,59 @@
+//go:build !confluent
+
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/Shopify/sarama"
+)
+
+// configureAuth applies cfg's TLS and SASL settings to config, so
+// doppler-api can talk to authenticated brokers such as Confluent Cloud,
+// MSK, or Aiven instead of only unauthenticated plaintext ones.
+func configureAuth(config *sarama.Config, cfg Config) error {
+	if cfg.TLSEnabled {
+		tlsConfig := &tls.Config{InsecureSkipVerify: cfg.TLSSkipVerify}
+
+		if cfg.TLSCAPath != "" {
+			caCert, err := os.ReadFile(cfg.TLSCAPath)
+			if err != nil {
+				return fmt.Errorf("error reading kafka TLS CA file: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caCert) {
+				return fmt.Errorf("error parsing kafka TLS CA file %q", cfg.TLSCAPath)
+			}
+			tlsConfig.RootCAs = pool
+		}
+
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	if cfg.SASLMechanism == "" {
+		return nil
+	}
+
+	config.Net.SASL.Enable = true
+	config.Net.SASL.User = cfg.SASLUsername
+	config.Net.SASL.Password = cfg.SASLPassword
+
+	switch cfg.SASLMechanism {
+	case "PLAIN":
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case "SCRAM-SHA-256":
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		config.Net.SASL.SCRAMClientGeneratorFunc = scramClientGenerator(sarama.SASLTypeSCRAMSHA256)
+	case "SCRAM-SHA-512":
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		config.Net.SASL.SCRAMClientGeneratorFunc = scramClientGenerator(sarama.SASLTypeSCRAMSHA512)
+	default:
+		return fmt.Errorf("unsupported KAFKA_SASL_MECHANISM %q", cfg.SASLMechanism)
+	}
+
+	return nil
+}
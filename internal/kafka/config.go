@@ -0,0 +1,46 @@
+package kafka
+
+import "time"
+
+// Config holds the settings needed to join a Kafka consumer group. It is
+// shared by every MessageSource implementation in this package (Sarama,
+// confluent-kafka-go, ...) so callers can switch implementations via
+// build tag without changing how they're configured.
+type Config struct {
+	Brokers        []string
+	Topic          string
+	GroupID        string
+	OffsetReset    string // "earliest" or "latest"
+	SessionTimeout time.Duration
+
+	// TLS, when enabled, wraps the broker connection in TLS. CAPath, if
+	// set, is used instead of the system trust store. SkipVerify disables
+	// certificate verification and should only be used against trusted
+	// non-production brokers.
+	TLSEnabled    bool
+	TLSCAPath     string
+	TLSSkipVerify bool
+
+	// SASL, when Mechanism is non-empty, authenticates with the broker
+	// using one of "PLAIN", "SCRAM-SHA-256", or "SCRAM-SHA-512".
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+}
+
+// PartitionStatus reports a single claimed partition's last-consumed
+// offset and lag behind its high water mark.
+type PartitionStatus struct {
+	Partition  int32 `json:"partition"`
+	LastOffset int64 `json:"lastOffset"`
+	Lag        int64 `json:"lag"`
+}
+
+// Status reports the current state of a Group's consumption, suitable
+// for exposing over the admin status endpoint. Partitions is reported
+// per partition rather than as a single scalar because a single
+// doppler-api instance can own more than one partition at once.
+type Status struct {
+	Paused     bool              `json:"paused"`
+	Partitions []PartitionStatus `json:"partitions"`
+}
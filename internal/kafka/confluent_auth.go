@@ -0,0 +1,46 @@
+//go:build confluent
+
+package kafka
+
+import ckafka "github.com/confluentinc/confluent-kafka-go/kafka"
+
+// configureConfluentAuth applies cfg's TLS and SASL settings to
+// configMap, mirroring configureAuth's Sarama equivalent so the
+// confluent-kafka-go build can talk to the same authenticated brokers
+// (Confluent Cloud, MSK, Aiven, ...).
+func configureConfluentAuth(configMap ckafka.ConfigMap, cfg Config) error {
+	if cfg.TLSEnabled {
+		if err := configMap.SetKey("security.protocol", "SSL"); err != nil {
+			return err
+		}
+		if cfg.TLSCAPath != "" {
+			if err := configMap.SetKey("ssl.ca.location", cfg.TLSCAPath); err != nil {
+				return err
+			}
+		}
+		if cfg.TLSSkipVerify {
+			if err := configMap.SetKey("enable.ssl.certificate.verification", false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.SASLMechanism == "" {
+		return nil
+	}
+
+	protocol := "SASL_PLAINTEXT"
+	if cfg.TLSEnabled {
+		protocol = "SASL_SSL"
+	}
+	if err := configMap.SetKey("security.protocol", protocol); err != nil {
+		return err
+	}
+	if err := configMap.SetKey("sasl.username", cfg.SASLUsername); err != nil {
+		return err
+	}
+	if err := configMap.SetKey("sasl.password", cfg.SASLPassword); err != nil {
+		return err
+	}
+	return configMap.SetKey("sasl.mechanisms", cfg.SASLMechanism)
+}
@@ -0,0 +1,194 @@
+//go:build confluent
+
+package kafka
+
+import (
+	"context"
+	"sort"
+
+	"github.com/acstech/doppler-api/internal/service"
+	ckafka "github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/rs/zerolog/log"
+)
+
+// Group is a confluent-kafka-go backed alternative to the Sarama
+// consumer group, built only when compiled with the "confluent" build
+// tag. It trades the CGo-free Sarama client for librdkafka, which some
+// operators prefer for its broader protocol support. NewGroup exposes
+// the same surface as the Sarama-backed Group (see consumer_group.go,
+// excluded by this build tag) so main.go doesn't need to know which
+// implementation it was built against.
+type Group struct {
+	consumer *ckafka.Consumer
+	topic    string
+	cm       *service.ConnectionManager
+	errs     chan error
+}
+
+// NewGroup joins cfg.GroupID against cfg.Brokers and subscribes to
+// cfg.Topic, ready to route claimed messages into cm.
+func NewGroup(cfg Config, cm *service.ConnectionManager) (*Group, error) {
+	offsetReset := "latest"
+	if cfg.OffsetReset == "earliest" {
+		offsetReset = "earliest"
+	}
+
+	configMap := ckafka.ConfigMap{
+		"bootstrap.servers":        joinBrokers(cfg.Brokers),
+		"group.id":                 cfg.GroupID,
+		"auto.offset.reset":        offsetReset,
+		"enable.auto.commit":       false,
+		"session.timeout.ms":       int(cfg.SessionTimeout.Milliseconds()),
+		"go.events.channel.enable": true,
+	}
+	if err := configureConfluentAuth(configMap, cfg); err != nil {
+		return nil, err
+	}
+
+	consumer, err := ckafka.NewConsumer(&configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := consumer.Subscribe(cfg.Topic, nil); err != nil {
+		return nil, err
+	}
+
+	return &Group{consumer: consumer, topic: cfg.Topic, cm: cm, errs: make(chan error, 16)}, nil
+}
+
+// Run consumes cfg.Topic into the Group's ConnectionManager until ctx is
+// cancelled. It should be run in its own goroutine.
+func (g *Group) Run(ctx context.Context) {
+	source := &confluentSource{consumer: g.consumer, errs: g.errs}
+	go source.pump(ctx)
+	g.cm.Consume(ctx, source)
+}
+
+// Pause stops message consumption without leaving the consumer group.
+func (g *Group) Pause() {
+	g.cm.Pause()
+}
+
+// Resume undoes a prior Pause.
+func (g *Group) Resume() {
+	g.cm.Resume()
+}
+
+// Errors surfaces errors encountered by the underlying consumer.
+func (g *Group) Errors() <-chan error {
+	return g.errs
+}
+
+// Close leaves the consumer group.
+func (g *Group) Close() error {
+	return g.consumer.Close()
+}
+
+// Status returns the Group's current pause state and, per partition
+// currently assigned to this instance, its last-consumed offset and lag
+// behind the partition's high water mark.
+func (g *Group) Status() Status {
+	offsets := g.cm.LastOffsets()
+
+	assignment, err := g.consumer.Assignment()
+	if err != nil {
+		log.Error().Err(err).Msg("error reading confluent consumer assignment")
+		return Status{Paused: g.cm.IsPaused()}
+	}
+
+	partitions := make([]PartitionStatus, 0, len(assignment))
+	for _, tp := range assignment {
+		lastOffset, ok := offsets[tp.Partition]
+		if !ok {
+			lastOffset = -1
+		}
+
+		var lag int64
+		if ok {
+			if _, high, err := g.consumer.GetWatermarkOffsets(*tp.Topic, tp.Partition); err == nil {
+				if lag = high - lastOffset - 1; lag < 0 {
+					lag = 0
+				}
+			}
+		}
+
+		partitions = append(partitions, PartitionStatus{
+			Partition:  tp.Partition,
+			LastOffset: lastOffset,
+			Lag:        lag,
+		})
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].Partition < partitions[j].Partition })
+
+	return Status{Paused: g.cm.IsPaused(), Partitions: partitions}
+}
+
+// confluentSource adapts a *ckafka.Consumer to service.MessageSource.
+type confluentSource struct {
+	consumer *ckafka.Consumer
+	errs     chan<- error
+	out      chan *service.Envelope
+}
+
+func (s *confluentSource) pump(ctx context.Context) {
+	s.out = make(chan *service.Envelope)
+	defer close(s.out)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-s.consumer.Events():
+			if !ok {
+				return
+			}
+			switch e := ev.(type) {
+			case *ckafka.Message:
+				envelope := &service.Envelope{
+					Topic:     *e.TopicPartition.Topic,
+					Partition: e.TopicPartition.Partition,
+					Offset:    int64(e.TopicPartition.Offset),
+					Key:       e.Key,
+					Value:     e.Value,
+				}
+				select {
+				case s.out <- envelope:
+				case <-ctx.Done():
+					return
+				}
+			case ckafka.Error:
+				select {
+				case s.errs <- e:
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (s *confluentSource) Messages() <-chan *service.Envelope {
+	return s.out
+}
+
+// Commit marks offset as processed so it is included in the consumer's
+// next offset commit.
+func (s *confluentSource) Commit(offset int64) error {
+	_, err := s.consumer.CommitOffsets([]ckafka.TopicPartition{{Offset: ckafka.Offset(offset + 1)}})
+	return err
+}
+
+func (s *confluentSource) Close() error {
+	return nil
+}
+
+func joinBrokers(brokers []string) string {
+	joined := ""
+	for i, b := range brokers {
+		if i > 0 {
+			joined += ","
+		}
+		joined += b
+	}
+	return joined
+}
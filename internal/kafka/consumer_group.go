@@ -0,0 +1,172 @@
+//go:build !confluent
+
+// Package kafka provides the Kafka consumer group subsystem used by
+// doppler-api to fan incoming messages out to the websocket service.
+package kafka
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/acstech/doppler-api/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// Group joins brokers as a member of a named consumer group and routes
+// claimed messages into a service.ConnectionManager. Running multiple
+// doppler-api instances with the same GroupID lets Kafka balance the
+// topic's partitions across them.
+type Group struct {
+	cg    sarama.ConsumerGroup
+	topic string
+	cm    *service.ConnectionManager
+
+	claimsMu sync.Mutex
+	claims   map[int32]sarama.ConsumerGroupClaim // keyed by partition; ConsumeClaim runs once per claimed partition
+}
+
+// Status returns the Group's current pause state and, per partition this
+// instance has claimed, its last-consumed offset and lag behind the
+// partition's high water mark.
+func (g *Group) Status() Status {
+	g.claimsMu.Lock()
+	claims := make(map[int32]sarama.ConsumerGroupClaim, len(g.claims))
+	for partition, claim := range g.claims {
+		claims[partition] = claim
+	}
+	g.claimsMu.Unlock()
+
+	offsets := g.cm.LastOffsets()
+
+	partitions := make([]PartitionStatus, 0, len(claims))
+	for partition, claim := range claims {
+		lastOffset, ok := offsets[partition]
+		if !ok {
+			lastOffset = -1
+		}
+
+		var lag int64
+		if ok {
+			if lag = claim.HighWaterMarkOffset() - lastOffset - 1; lag < 0 {
+				lag = 0
+			}
+		}
+
+		partitions = append(partitions, PartitionStatus{
+			Partition:  partition,
+			LastOffset: lastOffset,
+			Lag:        lag,
+		})
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].Partition < partitions[j].Partition })
+
+	return Status{
+		Paused:     g.cm.IsPaused(),
+		Partitions: partitions,
+	}
+}
+
+// Pause stops message consumption without leaving the consumer group.
+func (g *Group) Pause() {
+	g.cm.Pause()
+}
+
+// Resume undoes a prior Pause.
+func (g *Group) Resume() {
+	g.cm.Resume()
+}
+
+// NewGroup connects to cfg.Brokers and prepares a Group ready to consume
+// cfg.Topic as part of cfg.GroupID.
+func NewGroup(cfg Config, cm *service.ConnectionManager) (*Group, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_0_0_0
+	config.Consumer.Offsets.AutoCommit.Enable = false // offsets are marked and committed explicitly after dispatch, see saramaSource.Commit
+	config.Consumer.Return.Errors = true              // without this, consumer errors are swallowed instead of reaching Errors()
+
+	if cfg.OffsetReset == "earliest" {
+		config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	} else {
+		config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	}
+
+	if cfg.SessionTimeout > 0 {
+		config.Consumer.Group.Session.Timeout = cfg.SessionTimeout
+	}
+
+	if err := configureAuth(config, cfg); err != nil {
+		return nil, err
+	}
+
+	cg, err := sarama.NewConsumerGroup(cfg.Brokers, cfg.GroupID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Group{cg: cg, topic: cfg.Topic, cm: cm, claims: make(map[int32]sarama.ConsumerGroupClaim)}, nil
+}
+
+// Run joins the consumer group and consumes cfg.Topic until ctx is
+// cancelled, rebalancing automatically whenever group membership
+// changes. It should be run in its own goroutine.
+func (g *Group) Run(ctx context.Context) {
+	for {
+		if err := g.cg.Consume(ctx, []string{g.topic}, g); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error().Err(err).Msg("kafka consumer group error")
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Close leaves the consumer group, triggering a final rebalance.
+func (g *Group) Close() error {
+	return g.cg.Close()
+}
+
+// Errors surfaces errors encountered by the underlying consumer group.
+func (g *Group) Errors() <-chan error {
+	return g.cg.Errors()
+}
+
+// Setup is run once per session, at its start, before any ConsumeClaim
+// goroutines are started.
+func (g *Group) Setup(session sarama.ConsumerGroupSession) error {
+	log.Info().Int32("generation", session.GenerationID()).Msg("kafka consumer group session starting")
+	return nil
+}
+
+// Cleanup is run once per session, after all ConsumeClaim goroutines
+// have exited, typically right before a rebalance.
+func (g *Group) Cleanup(session sarama.ConsumerGroupSession) error {
+	log.Info().Int32("generation", session.GenerationID()).Msg("kafka consumer group session ending")
+	return nil
+}
+
+// ConsumeClaim wraps the claimed partition as a service.MessageSource and
+// hands it to the connection manager, which commits offsets on the
+// session once each batch has been dispatched.
+func (g *Group) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	partition := claim.Partition()
+
+	g.claimsMu.Lock()
+	g.claims[partition] = claim
+	g.claimsMu.Unlock()
+	defer func() {
+		g.claimsMu.Lock()
+		delete(g.claims, partition)
+		g.claimsMu.Unlock()
+	}()
+
+	source := newSaramaSource(session, claim)
+	defer source.Close()
+
+	g.cm.Consume(session.Context(), source)
+	return nil
+}
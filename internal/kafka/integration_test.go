@@ -0,0 +1,106 @@
+//go:build integration
+
+package kafka_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/acstech/doppler-api/internal/kafka"
+	"github.com/acstech/doppler-api/internal/service"
+	"github.com/gorilla/websocket"
+	tckafka "github.com/testcontainers/testcontainers-go/modules/kafka"
+)
+
+// TestGroupEndToEnd spins up a real Kafka broker via testcontainers-go,
+// produces a message onto a topic, and verifies it is delivered through
+// Group into a ConnectionManager's websocket batching pipeline by
+// actually reading it off a connected websocket client.
+func TestGroupEndToEnd(t *testing.T) {
+	ctx := context.Background()
+
+	broker, err := tckafka.Run(ctx, "confluentinc/confluent-local:7.5.0")
+	if err != nil {
+		t.Fatalf("error starting kafka testcontainer: %v", err)
+	}
+	defer func() {
+		if err := broker.Terminate(ctx); err != nil {
+			t.Logf("error terminating kafka testcontainer: %v", err)
+		}
+	}()
+
+	brokers, err := broker.Brokers(ctx)
+	if err != nil {
+		t.Fatalf("error fetching broker addresses: %v", err)
+	}
+
+	const topic = "doppler-integration-test"
+	const want = "hello from integration test"
+
+	producerConfig := sarama.NewConfig()
+	producerConfig.Producer.Return.Successes = true
+	producer, err := sarama.NewSyncProducer(brokers, producerConfig)
+	if err != nil {
+		t.Fatalf("error creating producer: %v", err)
+	}
+	defer producer.Close()
+
+	if _, _, err := producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.StringEncoder(want),
+	}); err != nil {
+		t.Fatalf("error producing test message: %v", err)
+	}
+
+	cm := service.NewConnectionManager(1, 1, 100, 1, nil)
+
+	// serve ConnectionManager over a real HTTP server so this test
+	// exercises the same websocket upgrade path a browser client would.
+	server := httptest.NewServer(cm)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("error dialing websocket: %v", err)
+	}
+	defer conn.Close()
+
+	group, err := kafka.NewGroup(kafka.Config{
+		Brokers:        brokers,
+		Topic:          topic,
+		GroupID:        "doppler-integration-test",
+		OffsetReset:    "earliest",
+		SessionTimeout: 6 * time.Second,
+	}, cm)
+	if err != nil {
+		t.Fatalf("error creating consumer group: %v", err)
+	}
+	defer group.Close()
+
+	runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	go group.Run(runCtx)
+
+	if err := conn.SetReadDeadline(time.Now().Add(25 * time.Second)); err != nil {
+		t.Fatalf("error setting read deadline: %v", err)
+	}
+
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("timed out waiting for the produced message to reach the websocket client: %v", err)
+	}
+
+	var batch []string
+	if err := json.Unmarshal(data, &batch); err != nil {
+		t.Fatalf("error unmarshaling dispatched batch: %v", err)
+	}
+	if len(batch) != 1 || batch[0] != want {
+		t.Fatalf("expected batch [%q], got %v", want, batch)
+	}
+}
@@ -0,0 +1,55 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/acstech/doppler-api/internal/service"
+)
+
+// MemorySource is an in-memory service.MessageSource backed by a slice of
+// envelopes, useful for exercising ConnectionManager's batching logic in
+// tests without a live Kafka broker.
+type MemorySource struct {
+	out chan *service.Envelope
+
+	mu      sync.Mutex
+	commits []int64
+	closed  bool
+}
+
+// NewMemorySource returns a MemorySource that delivers envelopes, in
+// order, then closes its channel.
+func NewMemorySource(envelopes ...*service.Envelope) *MemorySource {
+	out := make(chan *service.Envelope, len(envelopes))
+	for _, e := range envelopes {
+		out <- e
+	}
+	close(out)
+
+	return &MemorySource{out: out}
+}
+
+func (m *MemorySource) Messages() <-chan *service.Envelope {
+	return m.out
+}
+
+// Commits returns the offsets Commit has been called with, in order.
+func (m *MemorySource) Commits() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int64(nil), m.commits...)
+}
+
+func (m *MemorySource) Commit(offset int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commits = append(m.commits, offset)
+	return nil
+}
+
+func (m *MemorySource) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
@@ -0,0 +1,77 @@
+//go:build !confluent
+
+package kafka
+
+import (
+	"strconv"
+
+	"github.com/Shopify/sarama"
+	"github.com/acstech/doppler-api/internal/metrics"
+	"github.com/acstech/doppler-api/internal/service"
+)
+
+// saramaSource adapts a sarama.ConsumerGroupClaim/Session pair to
+// service.MessageSource.
+type saramaSource struct {
+	claim   sarama.ConsumerGroupClaim
+	session sarama.ConsumerGroupSession
+	out     chan *service.Envelope
+	done    chan struct{}
+}
+
+// newSaramaSource starts pumping claim's messages into a
+// service.MessageSource, converting each to an Envelope as it goes.
+func newSaramaSource(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) *saramaSource {
+	s := &saramaSource{
+		claim:   claim,
+		session: session,
+		out:     make(chan *service.Envelope),
+		done:    make(chan struct{}),
+	}
+	go s.pump()
+	return s
+}
+
+func (s *saramaSource) pump() {
+	defer close(s.out)
+	partition := strconv.Itoa(int(s.claim.Partition()))
+	for msg := range s.claim.Messages() {
+		metrics.MessagesConsumed.WithLabelValues(msg.Topic, partition).Inc()
+		if lag := s.claim.HighWaterMarkOffset() - msg.Offset - 1; lag >= 0 {
+			metrics.ConsumerLag.WithLabelValues(msg.Topic, partition).Set(float64(lag))
+		}
+
+		envelope := &service.Envelope{
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Key:       msg.Key,
+			Value:     msg.Value,
+		}
+		select {
+		case s.out <- envelope:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *saramaSource) Messages() <-chan *service.Envelope {
+	return s.out
+}
+
+// Commit marks offset as processed on the consumer group session and
+// commits it to the broker immediately, since AutoCommit is disabled in
+// favor of committing only after a batch has actually been dispatched.
+func (s *saramaSource) Commit(offset int64) error {
+	s.session.MarkOffset(s.claim.Topic(), s.claim.Partition(), offset+1, "")
+	s.session.Commit()
+	return nil
+}
+
+// Close stops pumping messages from claim. The underlying claim itself
+// is torn down by sarama once ConsumeClaim returns.
+func (s *saramaSource) Close() error {
+	close(s.done)
+	return nil
+}
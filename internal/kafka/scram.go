@@ -0,0 +1,58 @@
+//go:build !confluent
+
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// xdgSCRAMClient adapts xdg-go/scram to sarama.SCRAMClient so Sarama can
+// drive a SCRAM-SHA-256/512 handshake during SASL authentication.
+type xdgSCRAMClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+}
+
+func (c *xdgSCRAMClient) Begin(userName, password, authzID string) error {
+	client, err := c.HashGeneratorFcn.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.Client = client
+	c.ClientConversation = c.Client.NewConversation()
+	return nil
+}
+
+func (c *xdgSCRAMClient) Step(challenge string) (string, error) {
+	return c.ClientConversation.Step(challenge)
+}
+
+func (c *xdgSCRAMClient) Done() bool {
+	return c.ClientConversation.Done()
+}
+
+var sha256HashGeneratorFcn scram.HashGeneratorFcn = func() hash.Hash { return sha256.New() }
+var sha512HashGeneratorFcn scram.HashGeneratorFcn = func() hash.Hash { return sha512.New() }
+
+// scramClientGenerator returns the sarama.SCRAMClientGeneratorFunc for the
+// given SASL mechanism, or nil if mechanism isn't a SCRAM variant.
+func scramClientGenerator(mechanism sarama.SASLMechanism) func() sarama.SCRAMClient {
+	switch mechanism {
+	case sarama.SASLTypeSCRAMSHA256:
+		return func() sarama.SCRAMClient {
+			return &xdgSCRAMClient{HashGeneratorFcn: sha256HashGeneratorFcn}
+		}
+	case sarama.SASLTypeSCRAMSHA512:
+		return func() sarama.SCRAMClient {
+			return &xdgSCRAMClient{HashGeneratorFcn: sha512HashGeneratorFcn}
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+// Package metrics defines the Prometheus collectors doppler-api exposes
+// on /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// MessagesConsumed counts Kafka messages consumed, by topic and
+	// partition.
+	MessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "doppler_api_messages_consumed_total",
+		Help: "Total number of Kafka messages consumed, by topic and partition.",
+	}, []string{"topic", "partition"})
+
+	// ConsumerLag is the current lag behind the partition high water
+	// mark, by topic and partition.
+	ConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "doppler_api_consumer_lag",
+		Help: "Current consumer lag behind the partition high water mark, by topic and partition.",
+	}, []string{"topic", "partition"})
+
+	// BatchSize observes the number of messages in each dispatched batch.
+	BatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "doppler_api_batch_size",
+		Help:    "Number of messages in each dispatched batch.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+	})
+
+	// BatchFlushDuration observes how long it takes to broadcast a batch
+	// to every connected websocket client.
+	BatchFlushDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "doppler_api_batch_flush_duration_seconds",
+		Help:    "Time to broadcast a batch to all connected websocket clients.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// WebsocketClients is the number of currently connected websocket
+	// clients.
+	WebsocketClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "doppler_api_websocket_clients",
+		Help: "Number of currently connected websocket clients.",
+	})
+
+	// CouchbaseLatency observes the round-trip latency of Couchbase
+	// operations.
+	CouchbaseLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "doppler_api_couchbase_round_trip_seconds",
+		Help:    "Round-trip latency of Couchbase operations.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// InfluxLatency observes the round-trip latency of InfluxDB queries.
+	InfluxLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "doppler_api_influx_round_trip_seconds",
+		Help:    "Round-trip latency of InfluxDB queries.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// KafkaErrors counts errors reported by the Kafka consumer group.
+	KafkaErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "doppler_api_kafka_errors_total",
+		Help: "Total number of errors reported by the Kafka consumer group.",
+	})
+)
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/acstech/doppler-api/internal/couchbase"
+	"github.com/acstech/doppler-api/internal/metrics"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// upgrader upgrades incoming http connections on /receive/ws to websocket
+// connections.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// ConnectionManager maintains the set of connected websocket clients and
+// batches messages consumed from Kafka out to each of them.
+type ConnectionManager struct {
+	maxBatchSize  int
+	minBatchSize  int
+	batchInterval time.Duration
+	truncateSize  int
+	cbConn        *couchbase.Couchbase
+
+	clientsMu sync.Mutex
+	clients   map[*websocket.Conn]bool
+
+	paused int32 // set via atomic.StoreInt32, guarded by Pause/Resume/IsPaused
+
+	offsetsMu sync.Mutex
+	offsets   map[int32]int64 // last dispatched offset per partition, guarded by offsetsMu
+}
+
+// NewConnectionManager creates a ConnectionManager that batches up to
+// maxBatchSize messages (or at least minBatchSize once batchInterval
+// milliseconds have elapsed) before fanning them out to connected
+// websocket clients.
+func NewConnectionManager(maxBatchSize int, minBatchSize int, batchInterval int, truncateSize int, cbConn *couchbase.Couchbase) *ConnectionManager {
+	return &ConnectionManager{
+		maxBatchSize:  maxBatchSize,
+		minBatchSize:  minBatchSize,
+		batchInterval: time.Duration(batchInterval) * time.Millisecond,
+		truncateSize:  truncateSize,
+		cbConn:        cbConn,
+		clients:       make(map[*websocket.Conn]bool),
+		offsets:       make(map[int32]int64),
+	}
+}
+
+// ServeHTTP upgrades the request to a websocket connection and registers
+// it to receive batches of consumed messages.
+func (cm *ConnectionManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("websocket upgrade error")
+		return
+	}
+
+	cm.clientsMu.Lock()
+	cm.clients[conn] = true
+	cm.clientsMu.Unlock()
+	metrics.WebsocketClients.Inc()
+
+	go func() {
+		defer cm.removeClient(conn)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func (cm *ConnectionManager) removeClient(conn *websocket.Conn) {
+	cm.clientsMu.Lock()
+	delete(cm.clients, conn)
+	cm.clientsMu.Unlock()
+	conn.Close()
+	metrics.WebsocketClients.Dec()
+}
+
+// Pause stops Consume from draining source.Messages() and dispatching
+// batches, without tearing down the underlying connection or any of the
+// service's other downstream connections.
+func (cm *ConnectionManager) Pause() {
+	atomic.StoreInt32(&cm.paused, 1)
+}
+
+// Resume undoes a prior Pause, allowing Consume to drain messages again.
+func (cm *ConnectionManager) Resume() {
+	atomic.StoreInt32(&cm.paused, 0)
+}
+
+// IsPaused reports whether consumption is currently paused.
+func (cm *ConnectionManager) IsPaused() bool {
+	return atomic.LoadInt32(&cm.paused) == 1
+}
+
+// LastOffsets returns the offset of the most recently dispatched message
+// for each partition that has had at least one message dispatched.
+// ConsumeClaim runs one Consume loop per partition this instance owns,
+// so this is a map rather than a single scalar.
+func (cm *ConnectionManager) LastOffsets() map[int32]int64 {
+	cm.offsetsMu.Lock()
+	defer cm.offsetsMu.Unlock()
+
+	out := make(map[int32]int64, len(cm.offsets))
+	for partition, offset := range cm.offsets {
+		out[partition] = offset
+	}
+	return out
+}
+
+// Consume drains envelopes from source, batching them by size and
+// interval, and broadcasting each batch to every connected websocket
+// client. Once a batch is dispatched, source is committed up to its last
+// offset, so only delivered messages are acknowledged. While paused,
+// Consume stops draining source and dispatching batches until Resume is
+// called. Consume returns once ctx is done or source.Messages() is
+// closed.
+func (cm *ConnectionManager) Consume(ctx context.Context, source MessageSource) {
+	batch := make([]*Envelope, 0, cm.maxBatchSize)
+	ticker := time.NewTicker(cm.batchInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		cm.dispatch(batch)
+		last := batch[len(batch)-1]
+
+		cm.offsetsMu.Lock()
+		cm.offsets[last.Partition] = last.Offset
+		cm.offsetsMu.Unlock()
+
+		if err := source.Commit(last.Offset); err != nil {
+			log.Error().Err(err).Msg("error committing offset")
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		// a nil channel blocks forever, so while paused we simply stop
+		// selecting on source.Messages() without losing any buffered data
+		var messages <-chan *Envelope
+		if !cm.IsPaused() {
+			messages = source.Messages()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			batch = append(batch, msg)
+			if len(batch) >= cm.maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			if !cm.IsPaused() && len(batch) >= cm.minBatchSize {
+				flush()
+			}
+		}
+	}
+}
+
+// dispatch broadcasts a batch of messages to every connected websocket
+// client as a single JSON array of the messages' values as strings.
+func (cm *ConnectionManager) dispatch(batch []*Envelope) {
+	start := time.Now()
+	defer func() {
+		metrics.BatchSize.Observe(float64(len(batch)))
+		metrics.BatchFlushDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	payload := make([]string, len(batch))
+	for i, msg := range batch {
+		payload[i] = string(msg.Value)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Error().Err(err).Msg("error marshaling batch")
+		return
+	}
+
+	cm.clientsMu.Lock()
+	defer cm.clientsMu.Unlock()
+	for conn := range cm.clients {
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			log.Error().Err(err).Msg("error writing to websocket client")
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/acstech/doppler-api/internal/kafka"
+	"github.com/acstech/doppler-api/internal/service"
+)
+
+func TestConnectionManagerConsumeFlushesOnMaxBatchSize(t *testing.T) {
+	envelopes := []*service.Envelope{
+		{Topic: "test", Offset: 0, Value: []byte("one")},
+		{Topic: "test", Offset: 1, Value: []byte("two")},
+	}
+	source := kafka.NewMemorySource(envelopes...)
+
+	cm := service.NewConnectionManager(2, 1, 1000, 1, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	cm.Consume(ctx, source)
+
+	commits := source.Commits()
+	if len(commits) != 1 || commits[0] != 1 {
+		t.Fatalf("expected a single commit for offset 1, got %v", commits)
+	}
+	if got := cm.LastOffsets(); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("expected LastOffsets {0: 1}, got %v", got)
+	}
+}
+
+// manualSource is a service.MessageSource whose channel the test
+// controls directly, so it can assert on timing (a ticker firing, a
+// Pause blocking a drain) that kafka.MemorySource's pre-filled, already
+// closed channel can't exercise deterministically.
+type manualSource struct {
+	out chan *service.Envelope
+
+	mu      sync.Mutex
+	commits []int64
+}
+
+func newManualSource() *manualSource {
+	return &manualSource{out: make(chan *service.Envelope)}
+}
+
+func (m *manualSource) Messages() <-chan *service.Envelope {
+	return m.out
+}
+
+func (m *manualSource) Commit(offset int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commits = append(m.commits, offset)
+	return nil
+}
+
+func (m *manualSource) Commits() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int64(nil), m.commits...)
+}
+
+func (m *manualSource) Close() error {
+	return nil
+}
+
+func TestConnectionManagerConsumeFlushesOnTickerWithMinBatchSize(t *testing.T) {
+	source := newManualSource()
+	cm := service.NewConnectionManager(10, 1, 20, 1, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		cm.Consume(ctx, source)
+		close(done)
+	}()
+
+	source.out <- &service.Envelope{Topic: "test", Offset: 5, Value: []byte("one")}
+
+	// the batch never reaches maxBatchSize on its own, so the only way it
+	// gets flushed is the ticker noticing it has reached minBatchSize
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	commits := source.Commits()
+	if len(commits) != 1 || commits[0] != 5 {
+		t.Fatalf("expected a single commit for offset 5, got %v", commits)
+	}
+}
+
+func TestConnectionManagerConsumePausedHoldsMessagesUntilResumed(t *testing.T) {
+	source := newManualSource()
+	cm := service.NewConnectionManager(10, 1, 20, 1, nil)
+	cm.Pause()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		cm.Consume(ctx, source)
+		close(done)
+	}()
+
+	// while paused, Consume stops selecting on source.Messages(), so this
+	// send against the unbuffered channel can't complete yet
+	sent := make(chan struct{})
+	go func() {
+		source.out <- &service.Envelope{Topic: "test", Offset: 7, Value: []byte("one")}
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+		t.Fatal("message was drained while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cm.Resume()
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Consume to resume draining messages")
+	}
+
+	time.Sleep(100 * time.Millisecond) // let the ticker flush the batch
+	cancel()
+	<-done
+
+	commits := source.Commits()
+	if len(commits) != 1 || commits[0] != 7 {
+		t.Fatalf("expected a single commit for offset 7, got %v", commits)
+	}
+}
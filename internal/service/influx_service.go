@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/acstech/doppler-api/internal/metrics"
+	influx "github.com/influxdata/influxdb/client/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// InfluxService answers ajax requests for historical data by querying
+// InfluxDB directly.
+type InfluxService struct {
+	client       influx.Client
+	truncateSize int
+}
+
+// NewInfluxService creates an InfluxService backed by the given client.
+func NewInfluxService(client influx.Client, truncateSize int) *InfluxService {
+	return &InfluxService{
+		client:       client,
+		truncateSize: truncateSize,
+	}
+}
+
+// ServeHTTP runs the query supplied on the request and writes the result
+// back to the caller as JSON.
+func (s *InfluxService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := RequestIDFromContext(r.Context())
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	resp, err := s.client.Query(influx.NewQuery(query, "doppler", ""))
+	metrics.InfluxLatency.Observe(time.Since(start).Seconds())
+	if err != nil {
+		log.Error().Err(err).Str("requestId", requestID).Str("query", query).Msg("influx query error")
+		http.Error(w, "error querying influx", http.StatusInternalServerError)
+		return
+	}
+	if resp.Error() != nil {
+		log.Error().Err(resp.Error()).Str("requestId", requestID).Str("query", query).Msg("influx response error")
+		http.Error(w, "error querying influx", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp.Results); err != nil {
+		log.Error().Err(err).Str("requestId", requestID).Msg("error encoding influx response")
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+// Envelope is a transport-agnostic representation of a single consumed
+// message. It lets ConnectionManager batch and dispatch messages without
+// depending on any particular broker client library.
+type Envelope struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+}
+
+// MessageSource decouples ConnectionManager from any particular message
+// broker. Implementations exist for Sarama-backed Kafka, confluent-kafka-go,
+// and an in-memory queue used in tests; see internal/kafka.
+type MessageSource interface {
+	// Messages returns the channel envelopes are delivered on. It is
+	// closed once the source has no more messages to deliver.
+	Messages() <-chan *Envelope
+	// Close releases any resources held by the source.
+	Close() error
+	// Commit acknowledges that every envelope up to and including offset
+	// has been successfully dispatched.
+	Commit(offset int64) error
+}